@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strconv"
 
+	"github.com/Fantom-foundation/substate-cli/cmd/substate-cli/replay"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/substate"
 	"github.com/urfave/cli/v2"
@@ -16,6 +17,7 @@ var CloneCommand = cli.Command{
 	ArgsUsage: "<srcPath> <dstPath> <blockNumFirst> <blockNumLast>",
 	Flags: []cli.Flag{
 		&substate.WorkersFlag,
+		&replay.PrintConfigFlag,
 	},
 	Description: `
 The substate-cli db clone command requires four arguments:
@@ -46,6 +48,7 @@ func clone(ctx *cli.Context) error {
 	if first > last {
 		return fmt.Errorf("substate-cli db clone: error: first block has larger number than last block")
 	}
+	replay.PrintConfig(ctx)
 
 	srcBackend, err := rawdb.NewLevelDBDatabase(srcPath, 1024, 100, "srcDB", true)
 	if err != nil {
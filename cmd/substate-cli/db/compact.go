@@ -5,6 +5,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/Fantom-foundation/substate-cli/cmd/substate-cli/replay"
 	"github.com/syndtr/goleveldb/leveldb"
 	leveldb_opt "github.com/syndtr/goleveldb/leveldb/opt"
 	leveldb_util "github.com/syndtr/goleveldb/leveldb/util"
@@ -16,7 +17,9 @@ var CompactCommand = cli.Command{
 	Name:      "compact",
 	Usage:     "Compat LevelDB - discarding deleted and overwritten versions",
 	ArgsUsage: "<dbPath>",
-	Flags:     []cli.Flag{},
+	Flags: []cli.Flag{
+		&replay.PrintConfigFlag,
+	},
 	Description: `
 The substate-cli db compact command requires one argument:
 	<dbPath>
@@ -28,6 +31,7 @@ func compact(ctx *cli.Context) error {
 	if ctx.Args().Len() != 1 {
 		return fmt.Errorf("substate-cli db compact: command requires exactly one arguments")
 	}
+	replay.PrintConfig(ctx)
 
 	dbPath := ctx.Args().Get(0)
 	dbOpt := &leveldb_opt.Options{
@@ -0,0 +1,31 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/urfave/cli/v2"
+)
+
+func TestCompact_AcceptsPrintConfig(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "db")
+	db, err := leveldb.OpenFile(dbPath, nil)
+	if err != nil {
+		t.Fatalf("failed to create DB: %v", err)
+	}
+	if err := db.Put([]byte("key"), []byte("value"), nil); err != nil {
+		t.Fatalf("failed to write DB: %v", err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatalf("failed to close DB: %v", err)
+	}
+
+	app := &cli.App{
+		Name:     "substate-cli",
+		Commands: []*cli.Command{&CompactCommand},
+	}
+	if err := app.Run([]string{"substate-cli", "compact", "--print-config", dbPath}); err != nil {
+		t.Errorf("compact failed: %v", err)
+	}
+}
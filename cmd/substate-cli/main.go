@@ -44,6 +44,7 @@ func main() {
 			&replay.GetAddressStatsCommand,
 			&replay.GetKeyStatsCommand,
 			&replay.GetLocationStatsCommand,
+			&replay.VersionCommand,
 			&dbCommand,
 		},
 	}
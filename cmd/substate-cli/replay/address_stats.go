@@ -16,6 +16,8 @@ var GetAddressStatsCommand = cli.Command{
 		&substate.WorkersFlag,
 		&substate.SubstateDirFlag,
 		&ChainIDFlag,
+		&DryRunFlag,
+		&PrintConfigFlag,
 	},
 	Description: `
 The substate-cli address-stats command requires two arguments:
@@ -0,0 +1,112 @@
+package replay
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/urfave/cli/v2"
+)
+
+// ChainPresets maps the names accepted by --chain-preset to functions
+// creating a fresh copy of the corresponding chain configuration.
+var ChainPresets = map[string]func() *params.ChainConfig{
+	// Fantom main network.
+	"fantom": func() *params.ChainConfig {
+		return makeFantomChainConfig(250, 37455223, 37534833)
+	},
+	// Fantom test network.
+	"testnet": func() *params.ChainConfig {
+		return makeFantomChainConfig(4002, 1559470, 7513335)
+	},
+	// Ethereum main network.
+	"mainnet": func() *params.ChainConfig {
+		config := *params.MainnetChainConfig
+		return &config
+	},
+}
+
+// makeFantomChainConfig creates a chain configuration enabling all Ethash
+// protocol changes, with the Berlin and London forks activated at the
+// given blocks.
+func makeFantomChainConfig(chainId int64, berlinBlock, londonBlock uint64) *params.ChainConfig {
+	config := *params.AllEthashProtocolChanges
+	config.ChainID = big.NewInt(chainId)
+	config.BerlinBlock = new(big.Int).SetUint64(berlinBlock)
+	config.LondonBlock = new(big.Int).SetUint64(londonBlock)
+	return &config
+}
+
+// chainPresetNames lists the names of all chain presets in alphabetical order.
+func chainPresetNames() string {
+	names := make([]string, 0, len(ChainPresets))
+	for name := range ChainPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// loadChainConfig reads a chain configuration in the JSON format of
+// params.ChainConfig from the given file.
+func loadChainConfig(filename string) (*params.ChainConfig, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("substate-cli: cannot read chain config %s: %v", filename, err)
+	}
+	config := new(params.ChainConfig)
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("substate-cli: cannot parse chain config %s: %v", filename, err)
+	}
+	if config.ChainID == nil {
+		return nil, fmt.Errorf("substate-cli: chain config %s does not define a chain id", filename)
+	}
+	if err := config.CheckConfigForkOrder(); err != nil {
+		return nil, fmt.Errorf("substate-cli: invalid chain config %s: %v", filename, err)
+	}
+	return config, nil
+}
+
+// getChainConfig resolves the chain configuration selected by the command
+// line flags. It is either read from the file given by --chain-config or
+// taken from the selected preset. In both cases, it may be overridden by
+// explicitly setting the chain id or fork block flags, as long as the
+// resulting fork order remains valid.
+func getChainConfig(ctx *cli.Context) (*params.ChainConfig, error) {
+	var config *params.ChainConfig
+	if filename := ctx.String(ChainConfigFileFlag.Name); filename != "" {
+		if ctx.IsSet(ChainPresetFlag.Name) {
+			return nil, fmt.Errorf("substate-cli: --%v and --%v must not be used together", ChainConfigFileFlag.Name, ChainPresetFlag.Name)
+		}
+		var err error
+		config, err = loadChainConfig(filename)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		preset := ctx.String(ChainPresetFlag.Name)
+		makeConfig, exists := ChainPresets[preset]
+		if !exists {
+			return nil, fmt.Errorf("substate-cli: unknown chain preset %q, supported presets: %v", preset, chainPresetNames())
+		}
+		config = makeConfig()
+	}
+
+	if ctx.IsSet(ChainIDFlag.Name) {
+		config.ChainID = big.NewInt(int64(ctx.Int(ChainIDFlag.Name)))
+	}
+	if ctx.IsSet(BerlinBlockFlag.Name) {
+		config.BerlinBlock = new(big.Int).SetUint64(ctx.Uint64(BerlinBlockFlag.Name))
+	}
+	if ctx.IsSet(LondonBlockFlag.Name) {
+		config.LondonBlock = new(big.Int).SetUint64(ctx.Uint64(LondonBlockFlag.Name))
+	}
+	if err := config.CheckConfigForkOrder(); err != nil {
+		return nil, fmt.Errorf("substate-cli: invalid chain configuration: %v", err)
+	}
+	return config, nil
+}
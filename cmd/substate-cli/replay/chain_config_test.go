@@ -0,0 +1,249 @@
+package replay
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/substate"
+)
+
+func TestGetChainConfig_Presets(t *testing.T) {
+	tests := []struct {
+		preset      string
+		chainId     int64
+		berlinBlock int64
+		londonBlock int64
+	}{
+		{"fantom", 250, 37455223, 37534833},
+		{"testnet", 4002, 1559470, 7513335},
+		{"mainnet", 1, 12244000, 12965000},
+	}
+	for _, test := range tests {
+		t.Run(test.preset, func(t *testing.T) {
+			ctx := newTestContext(t, &ReplayCommand, "--chain-preset", test.preset)
+			config, err := getChainConfig(ctx)
+			if err != nil {
+				t.Fatalf("failed to get chain config: %v", err)
+			}
+			if got := config.ChainID.Int64(); got != test.chainId {
+				t.Errorf("unexpected chain id, wanted %v, got %v", test.chainId, got)
+			}
+			if got := config.BerlinBlock.Int64(); got != test.berlinBlock {
+				t.Errorf("unexpected Berlin block, wanted %v, got %v", test.berlinBlock, got)
+			}
+			if got := config.LondonBlock.Int64(); got != test.londonBlock {
+				t.Errorf("unexpected London block, wanted %v, got %v", test.londonBlock, got)
+			}
+		})
+	}
+}
+
+func TestGetChainConfig_DefaultsToFantomPreset(t *testing.T) {
+	config, err := getChainConfig(newTestContext(t, &ReplayCommand))
+	if err != nil {
+		t.Fatalf("failed to get chain config: %v", err)
+	}
+	if got := config.ChainID.Int64(); got != 250 {
+		t.Errorf("unexpected chain id, wanted 250, got %v", got)
+	}
+}
+
+func TestGetChainConfig_FlagsOverridePreset(t *testing.T) {
+	ctx := newTestContext(t, &ReplayCommand, "--chain-preset", "testnet", "--chainid", "7", "--berlin-block", "10", "--london-block", "20")
+	config, err := getChainConfig(ctx)
+	if err != nil {
+		t.Fatalf("failed to get chain config: %v", err)
+	}
+	if got := config.ChainID.Int64(); got != 7 {
+		t.Errorf("unexpected chain id, wanted 7, got %v", got)
+	}
+	if got := config.BerlinBlock.Int64(); got != 10 {
+		t.Errorf("unexpected Berlin block, wanted 10, got %v", got)
+	}
+	if got := config.LondonBlock.Int64(); got != 20 {
+		t.Errorf("unexpected London block, wanted 20, got %v", got)
+	}
+}
+
+func TestGetChainConfig_RejectsOverridesBreakingForkOrder(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"Berlin after preset London", []string{"--chain-preset", "fantom", "--berlin-block", "40000000"}},
+		{"London before preset Berlin", []string{"--chain-preset", "testnet", "--london-block", "10"}},
+		{"Berlin after file London", []string{"--chain-config", writeChainConfig(t, validChainConfig), "--berlin-block", "30"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := getChainConfig(newTestContext(t, &ReplayCommand, test.args...))
+			if err == nil || !strings.Contains(err.Error(), "invalid chain configuration") {
+				t.Errorf("expected an invalid fork order error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestGetChainConfig_OverridesDoNotModifyPresets(t *testing.T) {
+	ctx := newTestContext(t, &ReplayCommand, "--chainid", "7", "--berlin-block", "10")
+	if _, err := getChainConfig(ctx); err != nil {
+		t.Fatalf("failed to get chain config: %v", err)
+	}
+	config := ChainPresets["fantom"]()
+	if got := config.ChainID.Int64(); got != 250 {
+		t.Errorf("preset chain id was modified, wanted 250, got %v", got)
+	}
+	if got := config.BerlinBlock.Int64(); got != 37455223 {
+		t.Errorf("preset Berlin block was modified, wanted 37455223, got %v", got)
+	}
+}
+
+func TestGetChainConfig_UnknownPreset(t *testing.T) {
+	_, err := getChainConfig(newTestContext(t, &ReplayCommand, "--chain-preset", "unknown"))
+	if err == nil {
+		t.Fatalf("expected an error for an unknown preset")
+	}
+	for _, want := range []string{`unknown chain preset "unknown"`, "fantom, mainnet, testnet"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not contain %q", err, want)
+		}
+	}
+}
+
+// validChainConfig is a chain configuration activating all forks up to
+// Istanbul at genesis, Berlin at block 10, and London at block 20.
+const validChainConfig = `{
+	"chainId": 5,
+	"homesteadBlock": 0,
+	"eip150Block": 0,
+	"eip155Block": 0,
+	"eip158Block": 0,
+	"byzantiumBlock": 0,
+	"constantinopleBlock": 0,
+	"petersburgBlock": 0,
+	"istanbulBlock": 0,
+	"berlinBlock": 10,
+	"londonBlock": 20
+}`
+
+// writeChainConfig writes the given chain configuration to a temporary file
+// and returns its name.
+func writeChainConfig(t *testing.T, content string) string {
+	t.Helper()
+	name := filepath.Join(t.TempDir(), "chain.json")
+	if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write chain config: %v", err)
+	}
+	return name
+}
+
+func TestLoadChainConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		err     string
+	}{
+		{"valid", validChainConfig, ""},
+		{"missing chain id", `{"homesteadBlock": 0}`, "does not define a chain id"},
+		{"bad fork order", `{"chainId": 5, "homesteadBlock": 10, "eip150Block": 5}`, "invalid chain config"},
+		{"unparsable JSON", `{"chainId": 5,`, "cannot parse chain config"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config, err := loadChainConfig(writeChainConfig(t, test.content))
+			if test.err == "" {
+				if err != nil {
+					t.Fatalf("failed to load chain config: %v", err)
+				}
+				if got := config.ChainID.Int64(); got != 5 {
+					t.Errorf("unexpected chain id, wanted 5, got %v", got)
+				}
+				if got := config.LondonBlock.Int64(); got != 20 {
+					t.Errorf("unexpected London block, wanted 20, got %v", got)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("expected error containing %q, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestLoadChainConfig_MissingFile(t *testing.T) {
+	_, err := loadChainConfig(filepath.Join(t.TempDir(), "missing.json"))
+	if err == nil || !strings.Contains(err.Error(), "cannot read chain config") {
+		t.Errorf("expected a read error, got %v", err)
+	}
+}
+
+func TestGetChainConfig_ChainConfigFile(t *testing.T) {
+	file := writeChainConfig(t, validChainConfig)
+	tests := []struct {
+		name    string
+		args    []string
+		chainId int64
+		err     string
+	}{
+		{"file", []string{"--chain-config", file}, 5, ""},
+		{"file with chain id override", []string{"--chain-config", file, "--chainid", "7"}, 7, ""},
+		{"file with preset", []string{"--chain-config", file, "--chain-preset", "fantom"}, 0, "must not be used together"},
+		{"invalid file", []string{"--chain-config", writeChainConfig(t, `{}`)}, 0, "does not define a chain id"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config, err := getChainConfig(newTestContext(t, &ReplayCommand, test.args...))
+			if test.err == "" {
+				if err != nil {
+					t.Fatalf("failed to get chain config: %v", err)
+				}
+				if got := config.ChainID.Int64(); got != test.chainId {
+					t.Errorf("unexpected chain id, wanted %v, got %v", test.chainId, got)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("expected error containing %q, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestReplay_ChainConfigFileDeterminesEvmBehavior(t *testing.T) {
+	// The contract stores the chain id, so the recorded storage depends on
+	// the chain id, and the gas usage on whether Berlin is active. At block
+	// 15, validChainConfig activates Berlin, but the fantom preset does not.
+	recording := makeTestSubstate(15, 48794)
+	contract := common.HexToAddress("0x2000")
+	code := []byte{0x46, 0x60, 0x00, 0x55, 0x00} // CHAINID PUSH1 0 SSTORE STOP
+	recording.InputAlloc[contract].Code = code
+	recording.OutputAlloc[contract].Code = code
+	recording.OutputAlloc[contract].Storage[common.Hash{}] = common.BigToHash(big.NewInt(5))
+	dir := makeTestSubstateDB(t, map[uint64][]*substate.Substate{15: {recording}})
+	file := writeChainConfig(t, validChainConfig)
+
+	if err := runCommand(&ReplayCommand, "--substatedir", dir, "--chain-config", file, "15", "15"); err != nil {
+		t.Errorf("replay with chain config file failed: %v", err)
+	}
+
+	mismatchLog := filepath.Join(t.TempDir(), "mismatch.log")
+	if err := runCommand(&ReplayCommand, "--substatedir", dir, "--mismatch-log", mismatchLog, "15", "15"); err == nil {
+		t.Fatalf("replay with the default preset unexpectedly matched the recording")
+	}
+	data, err := os.ReadFile(mismatchLog)
+	if err != nil {
+		t.Fatalf("failed to read mismatch log: %v", err)
+	}
+	for _, want := range []string{
+		"Different gas usage:\n    want: 48794\n    have: 46904\n",
+		"have: " + common.BigToHash(big.NewInt(250)).String(),
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("mismatch log does not contain %q, got %q", want, data)
+		}
+	}
+}
@@ -22,6 +22,8 @@ var GetCodeCommand = cli.Command{
 		&substate.SubstateDirFlag,
 		&ContractDBFlag,
 		&ChainIDFlag,
+		&DryRunFlag,
+		&PrintConfigFlag,
 	},
 	Description: `
 The substate-cli code command requires two arguments:
@@ -88,11 +90,16 @@ func getCodeAction(ctx *cli.Context) error {
 		return fmt.Errorf("substate-cli storage command requires exactly 2 arguments")
 	}
 
+	if ctx.Bool(DryRunFlag.Name) {
+		return dryRun(ctx, "code")
+	}
+
 	chainID = ctx.Int(ChainIDFlag.Name)
 	fmt.Printf("chain-id: %v\n", chainID)
 	fmt.Printf("git-date: %v\n", gitDate)
 	fmt.Printf("git-commit: %v\n", gitCommit)
 	fmt.Printf("contract-db: %v\n", ContractDB)
+	PrintConfig(ctx)
 
 	first, last, argErr := SetBlockRange(ctx.Args().Get(0), ctx.Args().Get(1))
 	if argErr != nil {
@@ -100,6 +107,9 @@ func getCodeAction(ctx *cli.Context) error {
 	}
 
 	substate.SetSubstateFlags(ctx)
+	if err := CheckSubstateDir(ctx.String(substate.SubstateDirFlag.Name)); err != nil {
+		return err
+	}
 	substate.OpenSubstateDBReadOnly()
 	defer substate.CloseSubstateDB()
 
@@ -18,6 +18,8 @@ var GetCodeSizeCommand = cli.Command{
 		&substate.WorkersFlag,
 		&substate.SubstateDirFlag,
 		&ChainIDFlag,
+		&DryRunFlag,
+		&PrintConfigFlag,
 	},
 	Description: `
 The substate-cli code-size command requires two arguments:
@@ -81,10 +83,15 @@ func getCodeSizeAction(ctx *cli.Context) error {
 		return fmt.Errorf("substate-cli code-size command requires exactly 2 arguments")
 	}
 
+	if ctx.Bool(DryRunFlag.Name) {
+		return dryRun(ctx, "code-size")
+	}
+
 	chainID = ctx.Int(ChainIDFlag.Name)
 	fmt.Printf("chain-id: %v\n", chainID)
 	fmt.Printf("git-date: %v\n", gitDate)
 	fmt.Printf("git-commit: %v\n", gitCommit)
+	PrintConfig(ctx)
 
 	first, last, argErr := SetBlockRange(ctx.Args().Get(0), ctx.Args().Get(1))
 	if argErr != nil {
@@ -92,6 +99,9 @@ func getCodeSizeAction(ctx *cli.Context) error {
 	}
 
 	substate.SetSubstateFlags(ctx)
+	if err := CheckSubstateDir(ctx.String(substate.SubstateDirFlag.Name)); err != nil {
+		return err
+	}
 	substate.OpenSubstateDBReadOnly()
 	defer substate.CloseSubstateDB()
 
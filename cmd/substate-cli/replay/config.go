@@ -2,8 +2,12 @@ package replay
 
 import (
 	"fmt"
-	"github.com/urfave/cli/v2"
+	"os"
+	"path/filepath"
 	"strconv"
+
+	"github.com/ethereum/go-ethereum/substate"
+	"github.com/urfave/cli/v2"
 )
 
 // chain id
@@ -20,6 +24,23 @@ var (
 		Usage: "ChainID for replayer",
 		Value: 250,
 	}
+	ChainPresetFlag = cli.StringFlag{
+		Name:  "chain-preset",
+		Usage: "select the chain configuration used for replaying (" + chainPresetNames() + ")",
+		Value: "fantom",
+	}
+	ChainConfigFileFlag = cli.StringFlag{
+		Name:  "chain-config",
+		Usage: "the JSON file to read the chain configuration used for replaying from, replacing --chain-preset",
+	}
+	BerlinBlockFlag = cli.Uint64Flag{
+		Name:  "berlin-block",
+		Usage: "overrides the Berlin fork block of the selected chain configuration",
+	}
+	LondonBlockFlag = cli.Uint64Flag{
+		Name:  "london-block",
+		Usage: "overrides the London fork block of the selected chain configuration",
+	}
 	ProfileEVMCallFlag = cli.BoolFlag{
 		Name:  "profiling-call",
 		Usage: "enable profiling for EVM call",
@@ -36,6 +57,10 @@ var (
 		Name:  "only-successful",
 		Usage: "only runs transactions that have been successful",
 	}
+	CountOpcodesFlag = cli.BoolFlag{
+		Name:  "count-opcodes",
+		Usage: "count the executed opcodes (only supported by the geth interpreter)",
+	}
 	InterpreterImplFlag = cli.StringFlag{
 		Name:  "interpreter",
 		Usage: "select the interpreter version to be used",
@@ -44,6 +69,18 @@ var (
 		Name:  "cpuprofile",
 		Usage: "the file name where to write a CPU profile of the evaluation step to",
 	}
+	MemProfilingFlag = cli.StringFlag{
+		Name:  "memprofile",
+		Usage: "the file name where to write a heap profile at the end of the evaluation step to",
+	}
+	BlockProfilingFlag = cli.StringFlag{
+		Name:  "blockprofile",
+		Usage: "the file name where to write a goroutine blocking profile of the evaluation step to",
+	}
+	MutexProfilingFlag = cli.StringFlag{
+		Name:  "mutexprofile",
+		Usage: "the file name where to write a mutex contention profile of the evaluation step to",
+	}
 	UseInMemoryStateDbFlag = cli.BoolFlag{
 		Name:  "faststatedb",
 		Usage: "enables a faster, yet still experimental StateDB implementation",
@@ -58,6 +95,26 @@ var (
 		Usage: "set a buffer size for profiling channel",
 		Value: 100000,
 	}
+	MismatchLogFlag = cli.StringFlag{
+		Name:  "mismatch-log",
+		Usage: "the file name where to write details of transactions producing inconsistent output to; the replay continues after mismatches",
+	}
+	FailListFlag = cli.StringFlag{
+		Name:  "fail-list",
+		Usage: "the file name where to append block, transaction and reason of failing transactions to; the replay continues after failures",
+	}
+	GasCsvFlag = cli.StringFlag{
+		Name:  "gas-csv",
+		Usage: "the file name where to write the gas used by each transaction to in CSV format",
+	}
+	DryRunFlag = cli.BoolFlag{
+		Name:  "dry-run",
+		Usage: "validate the arguments and open the substate DB without processing any transactions",
+	}
+	PrintConfigFlag = cli.BoolFlag{
+		Name:  "print-config",
+		Usage: "print the resolved configuration of the command before processing",
+	}
 	// contract-db filename
 	ContractDBFlag = cli.StringFlag{
 		Name:  "contractdb",
@@ -80,3 +137,45 @@ func SetBlockRange(firstArg string, lastArg string) (uint64, uint64, error) {
 	}
 	return first, last, nil
 }
+
+// PrintConfig prints the git commit and date of the build and the resolved
+// values of all flags of the current command, if requested via --print-config.
+// Flags listed in skip are omitted, e.g. since the command prints values
+// derived from them instead.
+func PrintConfig(ctx *cli.Context, skip ...string) {
+	if !ctx.Bool(PrintConfigFlag.Name) {
+		return
+	}
+	fmt.Printf("config: git-commit = %v\n", gitCommit)
+	fmt.Printf("config: git-date = %v\n", gitDate)
+	skipped := map[string]bool{cli.HelpFlag.Names()[0]: true}
+	for _, name := range skip {
+		skipped[name] = true
+	}
+	for _, flag := range ctx.Command.Flags {
+		name := flag.Names()[0]
+		if skipped[name] {
+			continue
+		}
+		fmt.Printf("config: %v = %v\n", name, ctx.Value(name))
+	}
+}
+
+// CheckSubstateDir verifies that the given substate directory exists and
+// contains a LevelDB database.
+func CheckSubstateDir(dir string) error {
+	info, err := os.Stat(dir)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("substate-cli: error: substate directory %s does not exist, set its location via --%v", dir, substate.SubstateDirFlag.Name)
+	}
+	if err != nil {
+		return fmt.Errorf("substate-cli: error: cannot access substate directory %s: %v", dir, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("substate-cli: error: substate directory %s is not a directory", dir)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "CURRENT")); err != nil {
+		return fmt.Errorf("substate-cli: error: substate directory %s does not contain a substate DB", dir)
+	}
+	return nil
+}
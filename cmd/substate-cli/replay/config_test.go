@@ -0,0 +1,102 @@
+package replay
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/substate"
+)
+
+func TestCheckSubstateDir(t *testing.T) {
+	tmp := t.TempDir()
+	file := filepath.Join(tmp, "file")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+	empty := filepath.Join(tmp, "empty")
+	if err := os.Mkdir(empty, 0755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	valid := makeTestSubstateDB(t, map[uint64][]*substate.Substate{})
+
+	tests := []struct {
+		name string
+		dir  string
+		err  string
+	}{
+		{"missing directory", filepath.Join(tmp, "missing"), "does not exist"},
+		{"regular file", file, "is not a directory"},
+		{"empty directory", empty, "does not contain a substate DB"},
+		{"valid directory", valid, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := CheckSubstateDir(test.dir)
+			if test.err == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("expected error containing %q, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestPrintConfig_DisabledByDefault(t *testing.T) {
+	ctx := newTestContext(t, &ReplayForkCommand, "--hard-fork", "9069000")
+	if output := captureOutput(t, func() { PrintConfig(ctx) }); output != "" {
+		t.Errorf("unexpected output without --print-config: %q", output)
+	}
+}
+
+func TestPrintConfig_PrintsFlagValues(t *testing.T) {
+	ctx := newTestContext(t, &ReplayForkCommand, "--print-config", "--hard-fork", "9069000")
+	output := captureOutput(t, func() { PrintConfig(ctx) })
+	for _, want := range []string{
+		"config: git-commit = \n",
+		"config: hard-fork = 9069000\n",
+		"config: print-config = true\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output does not contain %q, got %q", want, output)
+		}
+	}
+	if strings.Contains(output, "config: help") {
+		t.Errorf("output contains the help flag, got %q", output)
+	}
+}
+
+func TestPrintConfig_OmitsSkippedFlags(t *testing.T) {
+	ctx := newTestContext(t, &ReplayCommand, "--print-config", "--chainid", "7")
+	output := captureOutput(t, func() { PrintConfig(ctx, ChainIDFlag.Name) })
+	if strings.Contains(output, "config: chainid") {
+		t.Errorf("output contains skipped flag, got %q", output)
+	}
+	if !strings.Contains(output, "config: chain-preset = fantom\n") {
+		t.Errorf("output does not contain the chain preset, got %q", output)
+	}
+}
+
+func TestReplay_PrintConfigReportsResolvedChainConfig(t *testing.T) {
+	dir := makeTestSubstateDB(t, map[uint64][]*substate.Substate{5: {makeTestSubstate(5, 28908)}})
+	output := captureOutput(t, func() {
+		if err := runCommand(&ReplayCommand, "--substatedir", dir, "--print-config", "--chain-preset", "testnet", "5", "5"); err != nil {
+			t.Errorf("replay failed: %v", err)
+		}
+	})
+	for _, flag := range []string{ChainIDFlag.Name, BerlinBlockFlag.Name, LondonBlockFlag.Name} {
+		if strings.Contains(output, "config: "+flag+" =") {
+			t.Errorf("output contains unresolved flag %v, got %q", flag, output)
+		}
+	}
+	for _, want := range []string{"config: chain-preset = testnet\n", "config: resolved chain configuration = {ChainID: 4002 "} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output does not contain %q, got %q", want, output)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package replay
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/substate"
+	"github.com/urfave/cli/v2"
+)
+
+// dryRun validates the block range arguments and opens the substate DB
+// without processing any transactions.
+func dryRun(ctx *cli.Context, cli_command string) error {
+	first, last, argErr := SetBlockRange(ctx.Args().Get(0), ctx.Args().Get(1))
+	if argErr != nil {
+		return argErr
+	}
+
+	substate.SetSubstateFlags(ctx)
+	if err := CheckSubstateDir(ctx.String(substate.SubstateDirFlag.Name)); err != nil {
+		return err
+	}
+	substate.OpenSubstateDBReadOnly()
+	defer substate.CloseSubstateDB()
+
+	fmt.Printf("substate-cli %v: dry run: block range %v-%v is valid and the substate DB could be opened\n", cli_command, first, last)
+	return nil
+}
@@ -0,0 +1,71 @@
+package replay
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/substate"
+	"github.com/urfave/cli/v2"
+)
+
+func TestDryRun_SupportedByAllRangeCommands(t *testing.T) {
+	dir := makeTestSubstateDB(t, map[uint64][]*substate.Substate{5: {makeTestSubstate(5, 28908)}})
+	for _, command := range []*cli.Command{
+		&ReplayCommand,
+		&ReplayForkCommand,
+		&GetAddressStatsCommand,
+		&GetKeyStatsCommand,
+		&GetLocationStatsCommand,
+		&GetCodeCommand,
+		&GetCodeSizeCommand,
+		&GetStorageUpdateSizeCommand,
+		&SubstateDumpCommand,
+	} {
+		t.Run(command.Name, func(t *testing.T) {
+			output := captureOutput(t, func() {
+				if err := runCommand(command, "--substatedir", dir, "--dry-run", "5", "5"); err != nil {
+					t.Errorf("dry run failed: %v", err)
+				}
+			})
+			want := "substate-cli " + command.Name + ": dry run: block range 5-5 is valid"
+			if !strings.Contains(output, want) {
+				t.Errorf("output does not contain %q, got %q", want, output)
+			}
+		})
+	}
+}
+
+func TestDryRun_ReplayHasNoSideEffects(t *testing.T) {
+	dir := makeTestSubstateDB(t, map[uint64][]*substate.Substate{5: {makeTestSubstate(5, 1)}})
+	out := t.TempDir()
+	files := map[string]string{
+		GasCsvFlag.Name:       filepath.Join(out, "gas.csv"),
+		MismatchLogFlag.Name:  filepath.Join(out, "mismatch.log"),
+		FailListFlag.Name:     filepath.Join(out, "fail.csv"),
+		CpuProfilingFlag.Name: filepath.Join(out, "cpu.prof"),
+		MemProfilingFlag.Name: filepath.Join(out, "mem.prof"),
+	}
+	args := []string{"--substatedir", dir, "--dry-run"}
+	for flag, file := range files {
+		args = append(args, "--"+flag, file)
+	}
+
+	if err := runCommand(&ReplayCommand, append(args, "5", "5")...); err != nil {
+		t.Fatalf("dry run failed: %v", err)
+	}
+	for flag, file := range files {
+		if _, err := os.Stat(file); !os.IsNotExist(err) {
+			t.Errorf("dry run created the --%v file %v", flag, file)
+		}
+	}
+}
+
+func TestDryRun_RejectsInvalidBlockRange(t *testing.T) {
+	dir := makeTestSubstateDB(t, map[uint64][]*substate.Substate{})
+	err := runCommand(&ReplayCommand, "--substatedir", dir, "--dry-run", "6", "5")
+	if err == nil || !strings.Contains(err.Error(), "first block has larger number than last block") {
+		t.Errorf("expected an invalid block range error, got %v", err)
+	}
+}
@@ -18,6 +18,8 @@ var GetKeyStatsCommand = cli.Command{
 		&substate.WorkersFlag,
 		&substate.SubstateDirFlag,
 		&ChainIDFlag,
+		&DryRunFlag,
+		&PrintConfigFlag,
 	},
 	Description: `
 The substate-cli key-stats command requires two arguments:
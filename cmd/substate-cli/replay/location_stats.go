@@ -18,6 +18,8 @@ var GetLocationStatsCommand = cli.Command{
 		&substate.WorkersFlag,
 		&substate.SubstateDirFlag,
 		&ChainIDFlag,
+		&DryRunFlag,
+		&PrintConfigFlag,
 	},
 	Description: `
 The substate-cli location-stats command requires two arguments:
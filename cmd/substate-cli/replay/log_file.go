@@ -0,0 +1,146 @@
+package replay
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// logFlushInterval is the default maximum time written log entries are kept
+// in memory before being flushed to the underlying file.
+const logFlushInterval = 1 * time.Second
+
+// LogFile is an output file shared by concurrently running replay tasks.
+// Each write is appended atomically with respect to other writes. Output
+// is buffered and flushed by a background routine every flush interval,
+// such that a crash loses at most the entries of the last flush interval.
+type LogFile struct {
+	name   string
+	mu     sync.Mutex
+	file   *os.File
+	writer *bufio.Writer
+	stop   chan struct{}
+	done   chan struct{}
+
+	flushInterval time.Duration
+
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// LogFileError is returned by failing operations on a LogFile. It allows
+// callers to distinguish output errors from errors of the replayed
+// transactions.
+type LogFileError struct {
+	Name string
+	Err  error
+}
+
+func (e *LogFileError) Error() string {
+	return fmt.Sprintf("substate-cli: error writing log file %s: %v", e.Name, e.Err)
+}
+
+func (e *LogFileError) Unwrap() error {
+	return e.Err
+}
+
+// CreateLogFile creates a new log file, truncating any existing file with
+// the given name.
+func CreateLogFile(name string) (*LogFile, error) {
+	return openLogFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, logFlushInterval)
+}
+
+// AppendLogFile opens a log file for appending, creating it if needed.
+func AppendLogFile(name string) (*LogFile, error) {
+	return openLogFile(name, os.O_WRONLY|os.O_CREATE|os.O_APPEND, logFlushInterval)
+}
+
+func openLogFile(name string, flag int, flushInterval time.Duration) (*LogFile, error) {
+	file, err := os.OpenFile(name, flag, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("substate-cli: cannot open log file %s: %v", name, err)
+	}
+	l := &LogFile{
+		name:          name,
+		file:          file,
+		writer:        bufio.NewWriter(file),
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+		flushInterval: flushInterval,
+	}
+	go l.runFlusher()
+	return l, nil
+}
+
+// runFlusher periodically flushes buffered entries until the file is closed.
+// A failed flush is reported by the next Write or Close, since bufio.Writer
+// keeps its first error.
+func (l *LogFile) runFlusher() {
+	defer close(l.done)
+	ticker := time.NewTicker(l.flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			l.mu.Lock()
+			l.writer.Flush()
+			l.mu.Unlock()
+		case <-l.stop:
+			return
+		}
+	}
+}
+
+// Write appends the given data to the log file.
+func (l *LogFile) Write(data []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	n, err := l.writer.Write(data)
+	if err != nil {
+		return n, &LogFileError{l.name, err}
+	}
+	return n, nil
+}
+
+// Printf appends a formatted entry to the log file.
+func (l *LogFile) Printf(format string, a ...any) error {
+	_, err := l.Write([]byte(fmt.Sprintf(format, a...)))
+	return err
+}
+
+// WriteCsv appends the given fields as a single CSV record, quoting them as
+// required by RFC 4180.
+func (l *LogFile) WriteCsv(fields ...string) error {
+	var record bytes.Buffer
+	writer := csv.NewWriter(&record)
+	if err := writer.Write(fields); err != nil {
+		return &LogFileError{l.name, err}
+	}
+	writer.Flush()
+	_, err := l.Write(record.Bytes())
+	return err
+}
+
+// Close flushes all buffered entries and closes the log file. Only the
+// first call has an effect; later calls return the result of the first.
+func (l *LogFile) Close() error {
+	l.closeOnce.Do(func() {
+		close(l.stop)
+		<-l.done
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if err := l.writer.Flush(); err != nil {
+			l.file.Close()
+			l.closeErr = &LogFileError{l.name, err}
+			return
+		}
+		if err := l.file.Close(); err != nil {
+			l.closeErr = &LogFileError{l.name, err}
+		}
+	})
+	return l.closeErr
+}
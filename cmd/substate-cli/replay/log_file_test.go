@@ -0,0 +1,135 @@
+package replay
+
+import (
+	"encoding/csv"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLogFile_EntriesAreFlushedWithoutFurtherWrites(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "log.txt")
+	log, err := openLogFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("failed to create log file: %v", err)
+	}
+	defer log.Close()
+
+	if err := log.Printf("%v,%v\n", 1, 2); err != nil {
+		t.Fatalf("failed to write entry: %v", err)
+	}
+
+	want := "1,2\n"
+	var got string
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatalf("failed to read log file: %v", err)
+		}
+		if got = string(data); got == want {
+			return
+		}
+	}
+	t.Errorf("entry was not flushed before close, wanted %q, got %q", want, got)
+}
+
+func TestLogFile_AppendKeepsExistingEntries(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "log.txt")
+	for _, entry := range []string{"first", "second"} {
+		log, err := AppendLogFile(name)
+		if err != nil {
+			t.Fatalf("failed to open log file: %v", err)
+		}
+		if err := log.Printf("%v\n", entry); err != nil {
+			t.Fatalf("failed to write entry: %v", err)
+		}
+		if err := log.Close(); err != nil {
+			t.Fatalf("failed to close log file: %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatalf("failed to read log file: %v", err)
+	}
+	if got, want := string(data), "first\nsecond\n"; got != want {
+		t.Errorf("unexpected log content, wanted %q, got %q", want, got)
+	}
+}
+
+func TestLogFile_CreateTruncatesExistingFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "log.txt")
+	if err := os.WriteFile(name, []byte("old\n"), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	log, err := CreateLogFile(name)
+	if err != nil {
+		t.Fatalf("failed to create log file: %v", err)
+	}
+	if err := log.Printf("new\n"); err != nil {
+		t.Fatalf("failed to write entry: %v", err)
+	}
+	if err := log.Close(); err != nil {
+		t.Fatalf("failed to close log file: %v", err)
+	}
+
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatalf("failed to read log file: %v", err)
+	}
+	if got, want := string(data), "new\n"; got != want {
+		t.Errorf("unexpected log content, wanted %q, got %q", want, got)
+	}
+}
+
+func TestLogFile_CloseReportsFailedFlush(t *testing.T) {
+	if _, err := os.Stat("/dev/full"); err != nil {
+		t.Skip("/dev/full is not available")
+	}
+	log, err := CreateLogFile("/dev/full")
+	if err != nil {
+		t.Fatalf("failed to create log file: %v", err)
+	}
+	if err := log.Printf("entry\n"); err != nil {
+		t.Fatalf("failed to write entry: %v", err)
+	}
+	err = log.Close()
+	var logErr *LogFileError
+	if !errors.As(err, &logErr) {
+		t.Fatalf("expected a log file error, got %v", err)
+	}
+	if again := log.Close(); again != err {
+		t.Errorf("repeated close returned a different result, wanted %v, got %v", err, again)
+	}
+}
+
+func TestLogFile_WriteCsvQuotesFields(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "log.csv")
+	log, err := CreateLogFile(name)
+	if err != nil {
+		t.Fatalf("failed to create log file: %v", err)
+	}
+	record := []string{"5", "0", `reason with "quotes", a comma and ünïcode`}
+	if err := log.WriteCsv(record...); err != nil {
+		t.Fatalf("failed to write record: %v", err)
+	}
+	if err := log.Close(); err != nil {
+		t.Fatalf("failed to close log file: %v", err)
+	}
+
+	file, err := os.Open(name)
+	if err != nil {
+		t.Fatalf("failed to open log file: %v", err)
+	}
+	defer file.Close()
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to parse log file as CSV: %v", err)
+	}
+	if want := [][]string{record}; !reflect.DeepEqual(records, want) {
+		t.Errorf("unexpected records, wanted %q, got %q", want, records)
+	}
+}
@@ -0,0 +1,77 @@
+package replay
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// opcodeCounter is a vm.Tracer counting the executed instructions of a
+// single transaction per opcode.
+type opcodeCounter struct {
+	counts [256]uint64
+}
+
+func (c *opcodeCounter) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+}
+
+func (c *opcodeCounter) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+	c.counts[op]++
+}
+
+func (c *opcodeCounter) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+}
+
+func (c *opcodeCounter) CaptureExit(output []byte, gasUsed uint64, err error) {
+}
+
+func (c *opcodeCounter) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
+}
+
+func (c *opcodeCounter) CaptureEnd(output []byte, gasUsed uint64, t time.Duration, err error) {
+}
+
+// OpcodeStatistics aggregates the opcode counts of all replayed transactions.
+type OpcodeStatistics struct {
+	mu     sync.Mutex
+	counts [256]uint64
+}
+
+// Merge adds the counts collected for a single transaction.
+func (s *OpcodeStatistics) Merge(c *opcodeCounter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for op, count := range c.counts {
+		s.counts[op] += count
+	}
+}
+
+// PrintSummary prints the number of executions of each opcode, in
+// descending order of their frequency.
+func (s *OpcodeStatistics) PrintSummary() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ops := make([]vm.OpCode, 0, len(s.counts))
+	var total uint64
+	for op, count := range s.counts {
+		if count > 0 {
+			ops = append(ops, vm.OpCode(op))
+			total += count
+		}
+	}
+	sort.Slice(ops, func(i, j int) bool {
+		if s.counts[ops[i]] != s.counts[ops[j]] {
+			return s.counts[ops[i]] > s.counts[ops[j]]
+		}
+		return ops[i] < ops[j]
+	})
+	for _, op := range ops {
+		fmt.Printf("opcode-count: %v, %d\n", op, s.counts[op])
+	}
+	fmt.Printf("substate-cli replay: total executed instructions: %d\n", total)
+}
@@ -0,0 +1,84 @@
+package replay
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/substate"
+)
+
+func TestOpcodeCounter_CountsExecutedInstructions(t *testing.T) {
+	counter := &opcodeCounter{}
+	for _, op := range []vm.OpCode{vm.PUSH1, vm.PUSH1, vm.ADD} {
+		counter.CaptureState(nil, 0, op, 0, 0, nil, nil, 0, nil)
+	}
+	if got := counter.counts[vm.PUSH1]; got != 2 {
+		t.Errorf("unexpected PUSH1 count, wanted 2, got %v", got)
+	}
+	if got := counter.counts[vm.ADD]; got != 1 {
+		t.Errorf("unexpected ADD count, wanted 1, got %v", got)
+	}
+}
+
+func TestOpcodeStatistics_MergeAddsCounts(t *testing.T) {
+	stats := new(OpcodeStatistics)
+	first := &opcodeCounter{}
+	first.counts[vm.ADD] = 2
+	first.counts[vm.STOP] = 1
+	second := &opcodeCounter{}
+	second.counts[vm.ADD] = 3
+	stats.Merge(first)
+	stats.Merge(second)
+
+	if got := stats.counts[vm.ADD]; got != 5 {
+		t.Errorf("unexpected ADD count, wanted 5, got %v", got)
+	}
+	if got := stats.counts[vm.STOP]; got != 1 {
+		t.Errorf("unexpected STOP count, wanted 1, got %v", got)
+	}
+}
+
+func TestOpcodeStatistics_PrintSummarySortsByFrequency(t *testing.T) {
+	stats := new(OpcodeStatistics)
+	counter := &opcodeCounter{}
+	counter.counts[vm.STOP] = 1
+	counter.counts[vm.ADD] = 1
+	counter.counts[vm.PUSH1] = 2
+	stats.Merge(counter)
+
+	output := captureOutput(t, stats.PrintSummary)
+	want := "opcode-count: PUSH1, 2\n" +
+		"opcode-count: STOP, 1\n" +
+		"opcode-count: ADD, 1\n" +
+		"substate-cli replay: total executed instructions: 4\n"
+	if output != want {
+		t.Errorf("unexpected summary, wanted %q, got %q", want, output)
+	}
+}
+
+func TestReplay_CountOpcodesReportsExecutedInstructions(t *testing.T) {
+	dir := makeTestSubstateDB(t, map[uint64][]*substate.Substate{5: {makeTestSubstate(5, 28908)}})
+	output := captureOutput(t, func() {
+		if err := runCommand(&ReplayCommand, "--substatedir", dir, "--count-opcodes", "5", "5"); err != nil {
+			t.Errorf("replay failed: %v", err)
+		}
+	})
+	for _, want := range []string{
+		"opcode-count: PUSH1, 2\n",
+		"opcode-count: ADD, 1\n",
+		"opcode-count: STOP, 1\n",
+		"total executed instructions: 4\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output does not contain %q, got %q", want, output)
+		}
+	}
+}
+
+func TestReplay_CountOpcodesRejectsUnsupportedInterpreter(t *testing.T) {
+	err := runCommand(&ReplayCommand, "--count-opcodes", "--interpreter", "lfvm", "5", "5")
+	if err == nil || !strings.Contains(err.Error(), `not supported by interpreter "lfvm"`) {
+		t.Errorf("expected an unsupported interpreter error, got %v", err)
+	}
+}
@@ -0,0 +1,46 @@
+package replay
+
+import (
+	"os"
+	"runtime"
+	"runtime/pprof"
+
+	"github.com/urfave/cli/v2"
+)
+
+// writeProfiles writes the heap, block, and mutex profiles requested via
+// the command line flags.
+func writeProfiles(ctx *cli.Context) error {
+	if name := ctx.String(MemProfilingFlag.Name); name != "" {
+		f, err := os.Create(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		runtime.GC() // get up-to-date statistics
+		if err := pprof.WriteHeapProfile(f); err != nil {
+			return err
+		}
+	}
+	if name := ctx.String(BlockProfilingFlag.Name); name != "" {
+		if err := writeProfile("block", name); err != nil {
+			return err
+		}
+	}
+	if name := ctx.String(MutexProfilingFlag.Name); name != "" {
+		if err := writeProfile("mutex", name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeProfile writes the named runtime profile to the given file.
+func writeProfile(profile string, name string) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return pprof.Lookup(profile).WriteTo(f, 0)
+}
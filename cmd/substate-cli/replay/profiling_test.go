@@ -0,0 +1,46 @@
+package replay
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteProfiles_WritesRequestedProfiles(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		MemProfilingFlag.Name:   filepath.Join(dir, "mem.prof"),
+		BlockProfilingFlag.Name: filepath.Join(dir, "block.prof"),
+		MutexProfilingFlag.Name: filepath.Join(dir, "mutex.prof"),
+	}
+	var args []string
+	for flag, file := range files {
+		args = append(args, "--"+flag, file)
+	}
+
+	if err := writeProfiles(newTestContext(t, &ReplayCommand, args...)); err != nil {
+		t.Fatalf("failed to write profiles: %v", err)
+	}
+
+	for flag, file := range files {
+		// Profiles are written as gzip-compressed protocol buffers.
+		f, err := os.Open(file)
+		if err != nil {
+			t.Fatalf("failed to open --%v profile: %v", flag, err)
+		}
+		defer f.Close()
+		reader, err := gzip.NewReader(f)
+		if err != nil {
+			t.Fatalf("--%v profile is not gzip-compressed: %v", flag, err)
+		}
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			t.Fatalf("failed to decompress --%v profile: %v", flag, err)
+		}
+		if len(data) == 0 {
+			t.Errorf("--%v profile is empty", flag)
+		}
+	}
+}
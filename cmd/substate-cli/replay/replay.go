@@ -3,10 +3,14 @@ package replay
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"math/big"
 	"os"
+	"runtime"
 	"runtime/pprof"
+	"strconv"
 	"strings"
 	"sync/atomic"
 	"time"
@@ -41,15 +45,28 @@ var ReplayCommand = cli.Command{
 		&substate.SkipCreateTxsFlag,
 		&substate.SubstateDirFlag,
 		&ChainIDFlag,
+		&ChainPresetFlag,
+		&ChainConfigFileFlag,
+		&BerlinBlockFlag,
+		&LondonBlockFlag,
 		&ProfileEVMCallFlag,
 		&MicroProfilingFlag,
 		&BasicBlockProfilingFlag,
+		&CountOpcodesFlag,
 		&DatabaseNameFlag,
 		&ChannelBufferSizeFlag,
 		&InterpreterImplFlag,
 		&OnlySuccessfulFlag,
 		&CpuProfilingFlag,
+		&MemProfilingFlag,
+		&BlockProfilingFlag,
+		&MutexProfilingFlag,
 		&UseInMemoryStateDbFlag,
+		&MismatchLogFlag,
+		&FailListFlag,
+		&GasCsvFlag,
+		&DryRunFlag,
+		&PrintConfigFlag,
 	},
 	Description: `
 The substate-cli replay command requires two arguments:
@@ -65,6 +82,10 @@ type ReplayConfig struct {
 	vm_impl          string
 	only_successful  bool
 	use_in_memory_db bool
+	chain_config     *params.ChainConfig
+	mismatch_log     *LogFile
+	opcode_stats     *OpcodeStatistics
+	gas_csv          *LogFile
 }
 
 // data collection execution context
@@ -118,10 +139,7 @@ func replayTask(config ReplayConfig, block uint64, tx int, recording *substate.S
 	vmConfig = opera.DefaultVMConfig
 	vmConfig.NoBaseFee = true
 
-	chainConfig = params.AllEthashProtocolChanges
-	chainConfig.ChainID = big.NewInt(int64(chainID))
-	chainConfig.LondonBlock = new(big.Int).SetUint64(37534833)
-	chainConfig.BerlinBlock = new(big.Int).SetUint64(37455223)
+	chainConfig = config.chain_config
 
 	var hashError error
 	getHash := func(num uint64) common.Hash {
@@ -169,8 +187,15 @@ func replayTask(config ReplayConfig, block uint64, tx int, recording *substate.S
 
 	msg := inputMessage.AsMessage()
 
-	vmConfig.Tracer = nil
-	vmConfig.Debug = false
+	var counter *opcodeCounter
+	if config.opcode_stats != nil {
+		counter = &opcodeCounter{}
+		vmConfig.Tracer = counter
+		vmConfig.Debug = true
+	} else {
+		vmConfig.Tracer = nil
+		vmConfig.Debug = false
+	}
 	vmConfig.InterpreterImpl = config.vm_impl
 	statedb.Prepare(txHash, txIndex)
 
@@ -183,6 +208,10 @@ func replayTask(config ReplayConfig, block uint64, tx int, recording *substate.S
 	msgResult, err := evmcore.ApplyMessage(evm, msg, gaspool)
 	addVmDuration(time.Since(start))
 
+	if counter != nil {
+		config.opcode_stats.Merge(counter)
+	}
+
 	if err != nil {
 		statedb.RevertToSnapshot(snapshot)
 		return err
@@ -192,6 +221,12 @@ func replayTask(config ReplayConfig, block uint64, tx int, recording *substate.S
 		return hashError
 	}
 
+	if config.gas_csv != nil {
+		if err := config.gas_csv.Printf("%v,%v,%v\n", block, tx, msgResult.UsedGas); err != nil {
+			return err
+		}
+	}
+
 	if chainConfig.IsByzantium(blockCtx.BlockNumber) {
 		statedb.Finalise(true)
 	} else {
@@ -216,14 +251,21 @@ func replayTask(config ReplayConfig, block uint64, tx int, recording *substate.S
 	r := outputResult.Equal(evmResult)
 	a := outputAlloc.Equal(evmAlloc)
 	if !(r && a) {
-		fmt.Printf("block: %v Transaction: %v\n", block, tx)
+		var diff strings.Builder
+		fmt.Fprintf(&diff, "block: %v Transaction: %v\n", block, tx)
 		if !r {
-			fmt.Printf("inconsistent output: result\n")
-			PrintResultDiffSummary(outputResult, evmResult)
+			fmt.Fprintf(&diff, "inconsistent output: result\n")
+			PrintResultDiffSummary(&diff, outputResult, evmResult)
 		}
 		if !a {
-			fmt.Printf("inconsistent output: alloc\n")
-			PrintAllocationDiffSummary(&outputAlloc, &evmAlloc)
+			fmt.Fprintf(&diff, "inconsistent output: alloc\n")
+			PrintAllocationDiffSummary(&diff, &outputAlloc, &evmAlloc)
+		}
+		fmt.Print(diff.String())
+		if config.mismatch_log != nil {
+			if err := config.mismatch_log.Printf("%s", diff.String()); err != nil {
+				return err
+			}
 		}
 		return fmt.Errorf("inconsistent output")
 	}
@@ -231,109 +273,114 @@ func replayTask(config ReplayConfig, block uint64, tx int, recording *substate.S
 	return nil
 }
 
-func printIfDifferent[T comparable](label string, want, have T) bool {
+func printIfDifferent[T comparable](w io.Writer, label string, want, have T) bool {
 	if want != have {
-		fmt.Printf("  Different %s:\n", label)
-		fmt.Printf("    want: %v\n", want)
-		fmt.Printf("    have: %v\n", have)
+		fmt.Fprintf(w, "  Different %s:\n", label)
+		fmt.Fprintf(w, "    want: %v\n", want)
+		fmt.Fprintf(w, "    have: %v\n", have)
 		return true
 	}
 	return false
 }
 
-func printIfDifferentBytes(label string, want, have []byte) bool {
+func printIfDifferentBytes(w io.Writer, label string, want, have []byte) bool {
 	if !bytes.Equal(want, have) {
-		fmt.Printf("  Different %s:\n", label)
-		fmt.Printf("    want: %v\n", want)
-		fmt.Printf("    have: %v\n", have)
+		fmt.Fprintf(w, "  Different %s:\n", label)
+		fmt.Fprintf(w, "    want: %v\n", want)
+		fmt.Fprintf(w, "    have: %v\n", have)
 		return true
 	}
 	return false
 }
 
-func printIfDifferentBigInt(label string, want, have *big.Int) bool {
+func printIfDifferentBigInt(w io.Writer, label string, want, have *big.Int) bool {
 	if want == nil && have == nil {
 		return false
 	}
 	if want == nil || have == nil || want.Cmp(have) != 0 {
-		fmt.Printf("  Different %s:\n", label)
-		fmt.Printf("    want: %v\n", want)
-		fmt.Printf("    have: %v\n", have)
+		fmt.Fprintf(w, "  Different %s:\n", label)
+		fmt.Fprintf(w, "    want: %v\n", want)
+		fmt.Fprintf(w, "    have: %v\n", have)
 		return true
 	}
 	return false
 }
 
-func PrintResultDiffSummary(want, have *substate.SubstateResult) {
-	printIfDifferent("status", want.Status, have.Status)
-	printIfDifferent("contract address", want.ContractAddress, have.ContractAddress)
-	printIfDifferent("gas usage", want.GasUsed, have.GasUsed)
-	printIfDifferent("log bloom filter", want.Bloom, have.Bloom)
-	if !printIfDifferent("log size", len(want.Logs), len(have.Logs)) {
+func PrintResultDiffSummary(w io.Writer, want, have *substate.SubstateResult) {
+	printIfDifferent(w, "status", want.Status, have.Status)
+	printIfDifferent(w, "contract address", want.ContractAddress, have.ContractAddress)
+	printIfDifferent(w, "gas usage", want.GasUsed, have.GasUsed)
+	printIfDifferent(w, "log bloom filter", want.Bloom, have.Bloom)
+	if !printIfDifferent(w, "log size", len(want.Logs), len(have.Logs)) {
 		for i := range want.Logs {
-			printLogDiffSummary(fmt.Sprintf("log[%d]", i), want.Logs[i], have.Logs[i])
+			printLogDiffSummary(w, fmt.Sprintf("log[%d]", i), want.Logs[i], have.Logs[i])
 		}
 	}
 }
 
-func printLogDiffSummary(label string, want, have *types.Log) {
-	printIfDifferent(fmt.Sprintf("%s.address", label), want.Address, have.Address)
-	if !printIfDifferent(fmt.Sprintf("%s.Topics size", label), len(want.Topics), len(have.Topics)) {
+func printLogDiffSummary(w io.Writer, label string, want, have *types.Log) {
+	printIfDifferent(w, fmt.Sprintf("%s.address", label), want.Address, have.Address)
+	if !printIfDifferent(w, fmt.Sprintf("%s.Topics size", label), len(want.Topics), len(have.Topics)) {
 		for i := range want.Topics {
-			printIfDifferent(fmt.Sprintf("%s.Topics[%d]", label, i), want.Topics[i], have.Topics[i])
+			printIfDifferent(w, fmt.Sprintf("%s.Topics[%d]", label, i), want.Topics[i], have.Topics[i])
 		}
 	}
-	printIfDifferentBytes(fmt.Sprintf("%s.data", label), want.Data, have.Data)
+	printIfDifferentBytes(w, fmt.Sprintf("%s.data", label), want.Data, have.Data)
 }
 
-func PrintAllocationDiffSummary(want, have *substate.SubstateAlloc) {
-	printIfDifferent("substate alloc size", len(*want), len(*have))
+func PrintAllocationDiffSummary(w io.Writer, want, have *substate.SubstateAlloc) {
+	printIfDifferent(w, "substate alloc size", len(*want), len(*have))
 	for key := range *want {
 		_, present := (*have)[key]
 		if !present {
-			fmt.Printf("    missing key=%v\n", key)
+			fmt.Fprintf(w, "    missing key=%v: want %v\n", key, formatAccount((*want)[key]))
 		}
 	}
 
 	for key := range *have {
 		_, present := (*want)[key]
 		if !present {
-			fmt.Printf("    extra key=%v\n", key)
+			fmt.Fprintf(w, "    extra key=%v: have %v\n", key, formatAccount((*have)[key]))
 		}
 	}
 
 	for key, is := range *have {
 		should, present := (*want)[key]
 		if present {
-			printAccountDiffSummary(fmt.Sprintf("key=%v:", key), should, is)
+			printAccountDiffSummary(w, fmt.Sprintf("key=%v:", key), should, is)
 		}
 	}
 }
 
-func printAccountDiffSummary(label string, want, have *substate.SubstateAccount) {
-	printIfDifferent(fmt.Sprintf("%s.Nonce", label), want.Nonce, have.Nonce)
-	printIfDifferentBigInt(fmt.Sprintf("%s.Balance", label), want.Balance, have.Balance)
-	printIfDifferentBytes(fmt.Sprintf("%s.Code", label), want.Code, have.Code)
+// formatAccount summarizes an account reported as missing or extra.
+func formatAccount(account *substate.SubstateAccount) string {
+	return fmt.Sprintf("nonce=%v balance=%v code=%x storage=%v", account.Nonce, account.Balance, account.Code, account.Storage)
+}
+
+func printAccountDiffSummary(w io.Writer, label string, want, have *substate.SubstateAccount) {
+	printIfDifferent(w, fmt.Sprintf("%s.Nonce", label), want.Nonce, have.Nonce)
+	printIfDifferentBigInt(w, fmt.Sprintf("%s.Balance", label), want.Balance, have.Balance)
+	printIfDifferentBytes(w, fmt.Sprintf("%s.Code", label), want.Code, have.Code)
 
-	printIfDifferent(fmt.Sprintf("len(%s.Storage)", label), len(want.Storage), len(have.Storage))
+	printIfDifferent(w, fmt.Sprintf("len(%s.Storage)", label), len(want.Storage), len(have.Storage))
 	for key := range want.Storage {
 		_, present := have.Storage[key]
 		if !present {
-			fmt.Printf("    %s.Storage misses key %v\n", label, key)
+			fmt.Fprintf(w, "    %s.Storage misses key %v (want %v)\n", label, key, want.Storage[key])
 		}
 	}
 
 	for key := range have.Storage {
 		_, present := want.Storage[key]
 		if !present {
-			fmt.Printf("    %s.Storage has extra key %v\n", label, key)
+			fmt.Fprintf(w, "    %s.Storage has extra key %v (have %v)\n", label, key, have.Storage[key])
 		}
 	}
 
 	for key, is := range have.Storage {
 		should, present := want.Storage[key]
 		if present {
-			printIfDifferent(fmt.Sprintf("%s.Storage[%v]", label, key), should, is)
+			printIfDifferent(w, fmt.Sprintf("%s.Storage[%v]", label, key), should, is)
 		}
 	}
 }
@@ -364,6 +411,19 @@ func replayAction(ctx *cli.Context) error {
 		return fmt.Errorf("substate-cli replay command requires exactly 2 arguments")
 	}
 
+	// Opcodes are counted by a tracer, which is only supported by the geth
+	// interpreter; other interpreters would silently report no instructions.
+	if vmImpl := ctx.String(InterpreterImplFlag.Name); ctx.Bool(CountOpcodesFlag.Name) && vmImpl != "" && !strings.EqualFold(vmImpl, "geth") {
+		return fmt.Errorf("substate-cli replay: --%v is not supported by interpreter %q, use the geth interpreter", CountOpcodesFlag.Name, vmImpl)
+	}
+
+	if ctx.Bool(DryRunFlag.Name) {
+		if _, err := getChainConfig(ctx); err != nil {
+			return err
+		}
+		return dryRun(ctx, "replay")
+	}
+
 	// spawn contexts for data collector workers
 	if ctx.Bool(MicroProfilingFlag.Name) {
 		var dcc [5]*MicroProfilingCollectorContext
@@ -415,10 +475,25 @@ func replayAction(ctx *cli.Context) error {
 		}()
 	}
 
-	chainID = ctx.Int(ChainIDFlag.Name)
+	chainConfig, err := getChainConfig(ctx)
+	if err != nil {
+		return err
+	}
+	chainID = int(chainConfig.ChainID.Int64())
+	if filename := ctx.String(ChainConfigFileFlag.Name); filename != "" {
+		fmt.Printf("chain-config: %v\n", filename)
+	} else {
+		fmt.Printf("chain-preset: %v\n", ctx.String(ChainPresetFlag.Name))
+	}
 	fmt.Printf("chain-id: %v\n", chainID)
 	fmt.Printf("git-date: %v\n", gitDate)
 	fmt.Printf("git-commit: %v\n", gitCommit)
+	// The chain id and fork blocks are reported by the resolved chain
+	// configuration, which also reflects the selected preset or file.
+	PrintConfig(ctx, ChainIDFlag.Name, BerlinBlockFlag.Name, LondonBlockFlag.Name)
+	if ctx.Bool(PrintConfigFlag.Name) {
+		fmt.Printf("config: resolved chain configuration = %v\n", chainConfig)
+	}
 
 	first, last, argErr := SetBlockRange(ctx.Args().Get(0), ctx.Args().Get(1))
 	if argErr != nil {
@@ -442,6 +517,9 @@ func replayAction(ctx *cli.Context) error {
 	}
 
 	substate.SetSubstateFlags(ctx)
+	if err := CheckSubstateDir(ctx.String(substate.SubstateDirFlag.Name)); err != nil {
+		return err
+	}
 	substate.OpenSubstateDBReadOnly()
 	defer substate.CloseSubstateDB()
 
@@ -456,24 +534,115 @@ func replayAction(ctx *cli.Context) error {
 		defer pprof.StopCPUProfile()
 	}
 
+	// Enable block and mutex profiling if requested.
+	if ctx.String(BlockProfilingFlag.Name) != "" {
+		runtime.SetBlockProfileRate(1)
+	}
+	if ctx.String(MutexProfilingFlag.Name) != "" {
+		runtime.SetMutexProfileFraction(1)
+	}
+
+	// Open the mismatch log if requested.
+	var mismatchLog *LogFile
+	if name := ctx.String(MismatchLogFlag.Name); name != "" {
+		mismatchLog, err = CreateLogFile(name)
+		if err != nil {
+			return err
+		}
+		defer mismatchLog.Close()
+	}
+
+	// Open the list of failing transactions if requested.
+	var failList *LogFile
+	if name := ctx.String(FailListFlag.Name); name != "" {
+		failList, err = AppendLogFile(name)
+		if err != nil {
+			return err
+		}
+		defer failList.Close()
+	}
+
+	// Open the gas usage CSV file if requested.
+	var gasCsv *LogFile
+	if name := ctx.String(GasCsvFlag.Name); name != "" {
+		gasCsv, err = CreateLogFile(name)
+		if err != nil {
+			return err
+		}
+		defer gasCsv.Close()
+		if err = gasCsv.Printf("block,tx,gasUsed\n"); err != nil {
+			return err
+		}
+	}
+
+	var opcodeStats *OpcodeStatistics
+	if ctx.Bool(CountOpcodesFlag.Name) {
+		opcodeStats = new(OpcodeStatistics)
+	}
+
 	var config = ReplayConfig{
 		vm_impl:          ctx.String(InterpreterImplFlag.Name),
 		only_successful:  ctx.Bool(OnlySuccessfulFlag.Name),
 		use_in_memory_db: ctx.Bool(UseInMemoryStateDbFlag.Name),
+		chain_config:     chainConfig,
+		mismatch_log:     mismatchLog,
+		opcode_stats:     opcodeStats,
+		gas_csv:          gasCsv,
 	}
 
+	// If failing transactions or mismatches are recorded, the replay
+	// continues after a failure and reports the number of failed
+	// transactions at the end.
+	continueOnFailure := failList != nil || mismatchLog != nil
+	var numFailures int64
 	task := func(block uint64, tx int, recording *substate.Substate, taskPool *substate.SubstateTaskPool) error {
-		return replayTask(config, block, tx, recording, taskPool)
+		err := replayTask(config, block, tx, recording, taskPool)
+		var logErr *LogFileError
+		if err == nil || !continueOnFailure || errors.As(err, &logErr) {
+			return err
+		}
+		atomic.AddInt64(&numFailures, 1)
+		if failList == nil {
+			return nil
+		}
+		return failList.WriteCsv(strconv.FormatUint(block, 10), strconv.Itoa(tx), err.Error())
 	}
 
 	resetVmDuration()
 	taskPool := substate.NewSubstateTaskPool("substate-cli replay", task, first, last, ctx)
 	err = taskPool.Execute()
+	if n := atomic.LoadInt64(&numFailures); err == nil && n > 0 {
+		err = fmt.Errorf("substate-cli replay: %v transactions failed", n)
+	}
 
 	fmt.Printf("substate-cli replay: net VM time: %v\n", getVmDuration())
 	if strings.HasSuffix(ctx.String(InterpreterImplFlag.Name), "-stats") {
 		lfvm.PrintCollectedInstructionStatistics()
 	}
+	if opcodeStats != nil {
+		opcodeStats.PrintSummary()
+	}
 
-	return err
+	// Log files are closed explicitly, since a failure of their final flush
+	// would otherwise leave them truncated without notice. A close error is
+	// reported even if the replay failed, as a truncated fail list would
+	// look like the complete list of failures.
+	for _, log := range []*LogFile{mismatchLog, failList, gasCsv} {
+		if log == nil {
+			continue
+		}
+		if closeErr := log.Close(); closeErr != nil {
+			if err == nil {
+				err = closeErr
+			} else {
+				err = fmt.Errorf("%v; %w", err, closeErr)
+			}
+		}
+	}
+
+	profileErr := writeProfiles(ctx)
+	if err != nil {
+		return err
+	}
+	return profileErr
 }
@@ -34,6 +34,8 @@ var ReplayForkCommand = cli.Command{
 		&substate.SkipCreateTxsFlag,
 		&HardForkFlag,
 		&substate.SubstateDirFlag,
+		&DryRunFlag,
+		&PrintConfigFlag,
 	},
 	Description: `
 The replay-fork command requires two arguments:
@@ -369,7 +371,15 @@ func replayForkAction(ctx *cli.Context) error {
 		*ReplayForkChainConfig = *tests.Forks["London"]
 	}
 
+	if ctx.Bool(DryRunFlag.Name) {
+		return dryRun(ctx, "replay-fork")
+	}
+	PrintConfig(ctx)
+
 	substate.SetSubstateFlags(ctx)
+	if err := CheckSubstateDir(ctx.String(substate.SubstateDirFlag.Name)); err != nil {
+		return err
+	}
 	substate.OpenSubstateDBReadOnly()
 	defer substate.CloseSubstateDB()
 
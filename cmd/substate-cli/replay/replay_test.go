@@ -0,0 +1,191 @@
+package replay
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/substate"
+)
+
+func TestReplay_FailListRecordsAllFailuresAndContinues(t *testing.T) {
+	// Both transactions are recorded with a wrong gas usage.
+	dir := makeTestSubstateDB(t, map[uint64][]*substate.Substate{
+		5: {makeTestSubstate(5, 1)},
+		6: {makeTestSubstate(6, 1)},
+	})
+	failList := filepath.Join(t.TempDir(), "fail.csv")
+
+	err := runCommand(&ReplayCommand, "--substatedir", dir, "--workers", "1", "--fail-list", failList, "5", "6")
+	if err == nil || !strings.Contains(err.Error(), "2 transactions failed") {
+		t.Errorf("expected replay to report 2 failed transactions, got %v", err)
+	}
+
+	data, err := os.ReadFile(failList)
+	if err != nil {
+		t.Fatalf("failed to read fail list: %v", err)
+	}
+	for _, want := range []string{"5,0,inconsistent output\n", "6,0,inconsistent output\n"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("fail list does not contain %q, got %q", want, data)
+		}
+	}
+}
+
+func TestPrintAllocationDiffSummary_ReportsWantedAndActualValues(t *testing.T) {
+	account := common.HexToAddress("0x1000")
+	missing := common.HexToAddress("0x2000")
+	extra := common.HexToAddress("0x3000")
+	key := common.HexToHash("0x01")
+	value := common.HexToHash("0x02")
+
+	want := substate.SubstateAlloc{
+		account: substate.NewSubstateAccount(1, big.NewInt(1000), nil),
+		missing: substate.NewSubstateAccount(2, big.NewInt(20), nil),
+	}
+	have := substate.SubstateAlloc{
+		account: substate.NewSubstateAccount(1, big.NewInt(999), nil),
+		extra:   substate.NewSubstateAccount(3, big.NewInt(30), nil),
+	}
+	have[account].Storage[key] = value
+
+	var diff strings.Builder
+	PrintAllocationDiffSummary(&diff, &want, &have)
+	for _, line := range []string{
+		"Different key=" + account.String() + ":.Balance:\n    want: 1000\n    have: 999\n",
+		"missing key=" + missing.String() + ": want nonce=2 balance=20",
+		"extra key=" + extra.String() + ": have nonce=3 balance=30",
+		".Storage has extra key " + key.String() + " (have " + value.String() + ")",
+	} {
+		if !strings.Contains(diff.String(), line) {
+			t.Errorf("diff does not contain %q, got:\n%v", line, diff.String())
+		}
+	}
+}
+
+func TestReplay_MismatchLogRecordsAllMismatches(t *testing.T) {
+	// Both transactions are recorded with an altered sender balance.
+	transactions := map[uint64][]*substate.Substate{
+		5: {makeTestSubstate(5, 28908)},
+		6: {makeTestSubstate(6, 28908)},
+	}
+	for _, substates := range transactions {
+		substates[0].OutputAlloc[common.HexToAddress("0x1000")].Balance = big.NewInt(42)
+	}
+	dir := makeTestSubstateDB(t, transactions)
+	mismatchLog := filepath.Join(t.TempDir(), "mismatch.log")
+
+	err := runCommand(&ReplayCommand, "--substatedir", dir, "--workers", "1", "--mismatch-log", mismatchLog, "5", "6")
+	if err == nil || !strings.Contains(err.Error(), "2 transactions failed") {
+		t.Errorf("expected replay to report 2 failed transactions, got %v", err)
+	}
+
+	data, err := os.ReadFile(mismatchLog)
+	if err != nil {
+		t.Fatalf("failed to read mismatch log: %v", err)
+	}
+	for _, want := range []string{"block: 5 Transaction: 0\n", "block: 6 Transaction: 0\n", "    want: 42\n"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("mismatch log does not contain %q, got %q", want, data)
+		}
+	}
+}
+
+func TestReplay_GasCsvRecordsGasUsedPerTransaction(t *testing.T) {
+	dir := makeTestSubstateDB(t, map[uint64][]*substate.Substate{
+		5: {makeTestSubstate(5, 28908)},
+		6: {makeTestSubstate(6, 28908), makeTestSubstate(6, 28908)},
+	})
+	gasCsv := filepath.Join(t.TempDir(), "gas.csv")
+
+	if err := runCommand(&ReplayCommand, "--substatedir", dir, "--workers", "1", "--gas-csv", gasCsv, "5", "6"); err != nil {
+		t.Fatalf("replay failed: %v", err)
+	}
+
+	data, err := os.ReadFile(gasCsv)
+	if err != nil {
+		t.Fatalf("failed to read gas CSV: %v", err)
+	}
+	// Transactions of a block are replayed concurrently, so rows may be
+	// written in any order after the header.
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if lines[0] != "block,tx,gasUsed" {
+		t.Errorf("unexpected gas CSV header, got %q", lines[0])
+	}
+	rows := lines[1:]
+	sort.Strings(rows)
+	want := []string{"5,0,28908", "6,0,28908", "6,1,28908"}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("unexpected gas CSV rows, wanted %v, got %v", want, rows)
+	}
+}
+
+func TestReplay_GasCsvReportsFailedFlush(t *testing.T) {
+	if _, err := os.Stat("/dev/full"); err != nil {
+		t.Skip("/dev/full is not available")
+	}
+	dir := makeTestSubstateDB(t, map[uint64][]*substate.Substate{5: {makeTestSubstate(5, 28908)}})
+	err := runCommand(&ReplayCommand, "--substatedir", dir, "--gas-csv", "/dev/full", "5", "5")
+	if err == nil || !strings.Contains(err.Error(), "error writing log file /dev/full") {
+		t.Errorf("expected a log file error, got %v", err)
+	}
+}
+
+func TestReplay_FailListReportsFailedFlush(t *testing.T) {
+	if _, err := os.Stat("/dev/full"); err != nil {
+		t.Skip("/dev/full is not available")
+	}
+	dir := makeTestSubstateDB(t, map[uint64][]*substate.Substate{5: {makeTestSubstate(5, 1)}})
+	err := runCommand(&ReplayCommand, "--substatedir", dir, "--fail-list", "/dev/full", "5", "5")
+	if err == nil || !strings.Contains(err.Error(), "error writing log file /dev/full") {
+		t.Errorf("expected a log file error, got %v", err)
+	}
+}
+
+func TestReplay_MismatchLogReportsFailedFlush(t *testing.T) {
+	if _, err := os.Stat("/dev/full"); err != nil {
+		t.Skip("/dev/full is not available")
+	}
+	dir := makeTestSubstateDB(t, map[uint64][]*substate.Substate{5: {makeTestSubstate(5, 1)}})
+	err := runCommand(&ReplayCommand, "--substatedir", dir, "--mismatch-log", "/dev/full", "5", "5")
+	if err == nil || !strings.Contains(err.Error(), "error writing log file /dev/full") {
+		t.Errorf("expected a log file error, got %v", err)
+	}
+}
+
+// makeRevertingSubstate creates a substate of a transaction calling a
+// contract that reverts, recorded with the given receipt status.
+func makeRevertingSubstate(block uint64, status uint64) *substate.Substate {
+	recording := makeTestSubstate(block, 28905)
+	contract := common.HexToAddress("0x2000")
+	code := []byte{0x60, 0x00, 0x60, 0x00, 0xfd} // PUSH1 0 PUSH1 0 REVERT
+	recording.InputAlloc[contract].Code = code
+	recording.OutputAlloc[contract].Code = code
+	recording.Result.Status = status
+	return recording
+}
+
+func TestReplay_RevertingTransactionIsNotAFailure(t *testing.T) {
+	dir := makeTestSubstateDB(t, map[uint64][]*substate.Substate{
+		5: {makeRevertingSubstate(5, types.ReceiptStatusFailed)},
+	})
+	if err := runCommand(&ReplayCommand, "--substatedir", dir, "5", "5"); err != nil {
+		t.Errorf("replay of reverting transaction failed: %v", err)
+	}
+}
+
+func TestReplay_RevertingTransactionRecordedAsSuccessfulIsReported(t *testing.T) {
+	dir := makeTestSubstateDB(t, map[uint64][]*substate.Substate{
+		5: {makeRevertingSubstate(5, types.ReceiptStatusSuccessful)},
+	})
+	err := runCommand(&ReplayCommand, "--substatedir", dir, "5", "5")
+	if err == nil || !strings.Contains(err.Error(), "inconsistent output") {
+		t.Errorf("expected an inconsistent output error, got %v", err)
+	}
+}
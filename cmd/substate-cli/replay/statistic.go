@@ -102,11 +102,16 @@ func getReferenceStatsActionWithConsumer[T comparable](ctx *cli.Context, cli_com
 		return fmt.Errorf("substate-cli %v command requires exactly 2 arguments", cli_command)
 	}
 
+	if ctx.Bool(DryRunFlag.Name) {
+		return dryRun(ctx, cli_command)
+	}
+
 	chainID = ctx.Int(ChainIDFlag.Name)
 	fmt.Printf("chain-id: %v\n", chainID)
 	fmt.Printf("git-date: %v\n", gitDate)
 	fmt.Printf("git-commit: %v\n", gitCommit)
 	fmt.Printf("contract-db: %v\n", ContractDB)
+	PrintConfig(ctx)
 
 	first, last, argErr := SetBlockRange(ctx.Args().Get(0), ctx.Args().Get(1))
 	if argErr != nil {
@@ -114,6 +119,9 @@ func getReferenceStatsActionWithConsumer[T comparable](ctx *cli.Context, cli_com
 	}
 
 	substate.SetSubstateFlags(ctx)
+	if err := CheckSubstateDir(ctx.String(substate.SubstateDirFlag.Name)); err != nil {
+		return err
+	}
 	substate.OpenSubstateDBReadOnly()
 	defer substate.CloseSubstateDB()
 
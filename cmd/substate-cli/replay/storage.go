@@ -18,6 +18,8 @@ var GetStorageUpdateSizeCommand = cli.Command{
 		&substate.WorkersFlag,
 		&substate.SubstateDirFlag,
 		&ChainIDFlag,
+		&DryRunFlag,
+		&PrintConfigFlag,
 	},
 	Description: `
 The substate-cli storage-size command requires two arguments:
@@ -109,10 +111,15 @@ func getStorageUpdateSizeAction(ctx *cli.Context) error {
 		return fmt.Errorf("substate-cli storage command requires exactly 2 arguments")
 	}
 
+	if ctx.Bool(DryRunFlag.Name) {
+		return dryRun(ctx, "storage-size")
+	}
+
 	chainID = ctx.Int(ChainIDFlag.Name)
 	fmt.Printf("chain-id: %v\n", chainID)
 	fmt.Printf("git-date: %v\n", gitDate)
 	fmt.Printf("git-commit: %v\n", gitCommit)
+	PrintConfig(ctx)
 
 	first, last, argErr := SetBlockRange(ctx.Args().Get(0), ctx.Args().Get(1))
 	if argErr != nil {
@@ -120,6 +127,9 @@ func getStorageUpdateSizeAction(ctx *cli.Context) error {
 	}
 
 	substate.SetSubstateFlags(ctx)
+	if err := CheckSubstateDir(ctx.String(substate.SubstateDirFlag.Name)); err != nil {
+		return err
+	}
 	substate.OpenSubstateDBReadOnly()
 	defer substate.CloseSubstateDB()
 
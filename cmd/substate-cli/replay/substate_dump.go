@@ -17,6 +17,8 @@ var SubstateDumpCommand = cli.Command{
 	Flags: []cli.Flag{
 		&substate.WorkersFlag,
 		&substate.SubstateDirFlag,
+		&DryRunFlag,
+		&PrintConfigFlag,
 	},
 	Description: `
 The substate-cli dump command requires two arguments:
@@ -62,12 +64,21 @@ func substateDumpAction(ctx *cli.Context) error {
 		return fmt.Errorf("substate-cli dump cammand requires exactly 2 arguments")
 	}
 
+	if ctx.Bool(DryRunFlag.Name) {
+		return dryRun(ctx, "dump")
+	}
+
+	PrintConfig(ctx)
+
 	first, last, argErr := SetBlockRange(ctx.Args().Get(0), ctx.Args().Get(1))
 	if argErr != nil {
 		return argErr
 	}
 
 	substate.SetSubstateFlags(ctx)
+	if err := CheckSubstateDir(ctx.String(substate.SubstateDirFlag.Name)); err != nil {
+		return err
+	}
 	substate.OpenSubstateDBReadOnly()
 	defer substate.CloseSubstateDB()
 
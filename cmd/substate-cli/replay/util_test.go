@@ -0,0 +1,127 @@
+package replay
+
+import (
+	"flag"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/substate"
+	"github.com/urfave/cli/v2"
+)
+
+func TestMain(m *testing.M) {
+	// Like the substate-cli application, tests replay in record-replay mode,
+	// in which the StateDB collects the post-transaction allocation.
+	substate.RecordReplay = true
+	os.Exit(m.Run())
+}
+
+// newTestContext creates a CLI context for the given command with the
+// given command line arguments parsed.
+func newTestContext(t *testing.T, command *cli.Command, args ...string) *cli.Context {
+	t.Helper()
+	set := flag.NewFlagSet(command.Name, flag.ContinueOnError)
+	for _, f := range command.Flags {
+		if err := f.Apply(set); err != nil {
+			t.Fatalf("failed to apply flag %v: %v", f.Names()[0], err)
+		}
+	}
+	if err := set.Parse(args); err != nil {
+		t.Fatalf("failed to parse arguments %v: %v", args, err)
+	}
+	ctx := cli.NewContext(cli.NewApp(), set, nil)
+	ctx.Command = command
+	return ctx
+}
+
+// captureOutput runs the given function and returns everything it printed
+// to stdout.
+func captureOutput(t *testing.T, run func()) string {
+	t.Helper()
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("failed to create pipe: %v", err)
+	}
+	stdout := os.Stdout
+	os.Stdout = writer
+	output := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(reader)
+		output <- string(data)
+	}()
+	defer func() {
+		os.Stdout = stdout
+	}()
+	run()
+	writer.Close()
+	return <-output
+}
+
+// runCommand runs the given command with the given arguments as the only
+// command of a CLI application.
+func runCommand(command *cli.Command, args ...string) error {
+	app := &cli.App{
+		Name:     "substate-cli",
+		Commands: []*cli.Command{command},
+	}
+	return app.Run(append([]string{"substate-cli", command.Name}, args...))
+}
+
+// makeTestSubstate creates a substate of a transaction calling a small
+// contract, recorded with the given gas usage.
+func makeTestSubstate(block uint64, gasUsed uint64) *substate.Substate {
+	from := common.HexToAddress("0x1000")
+	to := common.HexToAddress("0x2000")
+	code := []byte{0x60, 0x01, 0x60, 0x02, 0x01, 0x00} // PUSH1 1 PUSH1 2 ADD STOP
+	inputAlloc := substate.SubstateAlloc{
+		from: substate.NewSubstateAccount(0, big.NewInt(1e18), nil),
+		to:   substate.NewSubstateAccount(1, big.NewInt(0), code),
+	}
+	outputAlloc := substate.SubstateAlloc{
+		from: substate.NewSubstateAccount(1, big.NewInt(1e18), nil),
+		to:   substate.NewSubstateAccount(1, big.NewInt(0), code),
+	}
+	env := &substate.SubstateEnv{
+		Coinbase:    common.HexToAddress("0x3000"),
+		Difficulty:  big.NewInt(0),
+		GasLimit:    10_000_000,
+		Number:      block,
+		Timestamp:   100,
+		BlockHashes: map[uint64]common.Hash{},
+	}
+	message := &substate.SubstateMessage{
+		CheckNonce: true,
+		GasPrice:   big.NewInt(0),
+		Gas:        100_000,
+		From:       from,
+		To:         &to,
+		Value:      big.NewInt(0),
+		GasFeeCap:  big.NewInt(0),
+		GasTipCap:  big.NewInt(0),
+	}
+	result := &substate.SubstateResult{
+		Status:  1,
+		GasUsed: gasUsed,
+	}
+	return substate.NewSubstate(inputAlloc, outputAlloc, env, message, result)
+}
+
+// makeTestSubstateDB creates a substate DB containing the given
+// transactions in a temporary directory and returns the directory.
+func makeTestSubstateDB(t *testing.T, transactions map[uint64][]*substate.Substate) string {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "substate")
+	substate.SetSubstateDirectory(dir)
+	substate.OpenSubstateDB()
+	for block, substates := range transactions {
+		for tx, st := range substates {
+			substate.PutSubstate(block, tx, st)
+		}
+	}
+	substate.CloseSubstateDB()
+	return dir
+}
@@ -0,0 +1,29 @@
+package replay
+
+import (
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+)
+
+// substate-cli version command
+var VersionCommand = cli.Command{
+	Action: versionAction,
+	Name:   "version",
+	Usage:  "prints the version and build information of substate-cli",
+	Flags: []cli.Flag{
+		&PrintConfigFlag,
+	},
+	Description: `
+The substate-cli version command prints the git commit and date the binary
+was built from, as well as the default chain id used by the replayer.`,
+}
+
+// func versionAction for VersionCommand
+func versionAction(ctx *cli.Context) error {
+	fmt.Printf("git-commit: %v\n", gitCommit)
+	fmt.Printf("git-date: %v\n", gitDate)
+	fmt.Printf("chain-id: %v\n", ChainIDFlag.Value)
+	PrintConfig(ctx)
+	return nil
+}
@@ -0,0 +1,40 @@
+package replay
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVersion_PrintsBuildInformation(t *testing.T) {
+	defer func(commit, date string) {
+		gitCommit, gitDate = commit, date
+	}(gitCommit, gitDate)
+	gitCommit = "0123456789abcdef0123456789abcdef01234567"
+	gitDate = "20220101"
+
+	output := captureOutput(t, func() {
+		if err := runCommand(&VersionCommand); err != nil {
+			t.Errorf("version command failed: %v", err)
+		}
+	})
+	for _, want := range []string{
+		"git-commit: 0123456789abcdef0123456789abcdef01234567\n",
+		"git-date: 20220101\n",
+		"chain-id: 250\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output does not contain %q, got %q", want, output)
+		}
+	}
+}
+
+func TestVersion_PrintConfig(t *testing.T) {
+	output := captureOutput(t, func() {
+		if err := runCommand(&VersionCommand, "--print-config"); err != nil {
+			t.Errorf("version command failed: %v", err)
+		}
+	})
+	if want := "config: print-config = true\n"; !strings.Contains(output, want) {
+		t.Errorf("output does not contain %q, got %q", want, output)
+	}
+}